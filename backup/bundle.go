@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/packfile"
+	"gopkg.in/src-d/go-git.v4/plumbing/revlist"
+)
+
+const bundleSignature = "# v2 git bundle"
+
+// collectRefs returns every hash reference of r keyed by full ref name,
+// along with the hash HEAD points to, if any.
+func collectRefs(r *git.Repository) (map[string]string, string, error) {
+	iter, err := r.References()
+	if err != nil {
+		return nil, "", err
+	}
+	defer iter.Close()
+
+	refs := map[string]string{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		refs[ref.Name().String()] = ref.Hash().String()
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var head string
+	if h, err := r.Head(); err == nil {
+		head = h.Hash().String()
+	}
+
+	return refs, head, nil
+}
+
+// writeBundle writes a git bundle containing refs and every object
+// reachable through them to w, equivalent to `git bundle create --all`.
+func writeBundle(r *git.Repository, refs map[string]string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintln(bw, bundleSignature); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tips := make([]plumbing.Hash, 0, len(names))
+	for _, name := range names {
+		hash := refs[name]
+		if _, err := fmt.Fprintf(bw, "%s %s\n", hash, name); err != nil {
+			return err
+		}
+
+		tips = append(tips, plumbing.NewHash(hash))
+	}
+
+	if _, err := fmt.Fprintln(bw); err != nil {
+		return err
+	}
+
+	// Encode only packs exactly the hashes it is given, it does not walk
+	// history/trees/blobs itself, so the tips must first be expanded to
+	// every object they reach.
+	hashes, err := revlist.Objects(r.Storer, tips, nil)
+	if err != nil {
+		return err
+	}
+
+	enc := packfile.NewEncoder(bw, r.Storer, false)
+	if _, err := enc.Encode(hashes, 10); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// readBundle parses the ref listing and packfile written by writeBundle,
+// storing the objects in r and setting the listed refs.
+func readBundle(r *git.Repository, reader io.Reader) error {
+	br := bufio.NewReader(reader)
+
+	sig, err := br.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(sig) != bundleSignature {
+		return ErrInvalidBundle.New(sig)
+	}
+
+	refs := map[plumbing.ReferenceName]plumbing.Hash{}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return ErrInvalidBundle.New(line)
+		}
+
+		refs[plumbing.ReferenceName(fields[1])] = plumbing.NewHash(fields[0])
+	}
+
+	if err := packfile.UpdateObjectStorage(r.Storer, br); err != nil {
+		return err
+	}
+
+	for name, hash := range refs {
+		if err := r.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
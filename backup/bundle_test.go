@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func newTestRepository(t *testing.T) *git.Repository {
+	t.Helper()
+
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+
+	w, err := r.Worktree()
+	require.NoError(t, err)
+
+	sig := &object.Signature{Name: "tester", Email: "tester@example.com", When: time.Unix(0, 0)}
+
+	for i, name := range []string{"a", "b"} {
+		f, err := w.Filesystem.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(name))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = w.Add(name)
+		require.NoError(t, err)
+
+		_, err = w.Commit("commit "+string(rune('0'+i)), &git.CommitOptions{Author: sig})
+		require.NoError(t, err)
+	}
+
+	return r
+}
+
+// TestWriteBundleIncludesHistory makes sure writeBundle packs every object
+// a ref's tip reaches, not just the tip commit itself: a bundle restored
+// into a bare repository must still be able to resolve the tip's parent.
+func TestWriteBundleIncludesHistory(t *testing.T) {
+	require := require.New(t)
+
+	src := newTestRepository(t)
+	refs, _, err := collectRefs(src)
+	require.NoError(err)
+	require.Len(refs, 1)
+
+	var buf bytes.Buffer
+	require.NoError(writeBundle(src, refs, &buf))
+
+	dst, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(err)
+	require.NoError(readBundle(dst, &buf))
+
+	for name, hash := range refs {
+		ref, err := dst.Storer.Reference(plumbing.ReferenceName(name))
+		require.NoError(err)
+		require.Equal(hash, ref.Hash().String())
+
+		commit, err := dst.CommitObject(ref.Hash())
+		require.NoError(err)
+		require.Len(commit.ParentHashes, 1)
+
+		parent, err := dst.CommitObject(commit.ParentHashes[0])
+		require.NoError(err, "bundle must include parent commits, not just ref tips")
+		require.Equal(0, parent.NumParents(), "first commit of the test repo should have no parent")
+	}
+}
@@ -0,0 +1,43 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+// LocalSink is a Sink backed by a billy.Filesystem.
+type LocalSink struct {
+	fs billy.Filesystem
+}
+
+// NewLocalSink creates a LocalSink rooted at fs.
+func NewLocalSink(fs billy.Filesystem) *LocalSink {
+	return &LocalSink{fs: fs}
+}
+
+// GetWriter implements Sink.
+func (s *LocalSink) GetWriter(ctx context.Context, relPath string) (io.WriteCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if dir := filepath.Dir(relPath); dir != "." {
+		if err := s.fs.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.fs.Create(relPath)
+}
+
+// GetReader implements Sink.
+func (s *LocalSink) GetReader(ctx context.Context, relPath string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.fs.Open(relPath)
+}
@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4/utils/ioutil"
+)
+
+// ManifestEntry describes a single repository captured by a backup.
+type ManifestEntry struct {
+	RepositoryID borges.RepositoryID `json:"repository_id"`
+	LocationID   borges.LocationID   `json:"location_id"`
+	LibraryID    borges.LibraryID    `json:"library_id"`
+	Refs         map[string]string   `json:"refs"`
+	Head         string              `json:"head,omitempty"`
+	BundlePath   string              `json:"bundle_path"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// Manifest is the JSON document written alongside the bundles of a backup,
+// listing what each bundle contains and where it lives in a Sink.
+type Manifest struct {
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// indexManifest indexes the entries of a manifest by repository, so a later
+// backup can look up what was captured of a repository last time. A nil
+// manifest indexes to an empty map, treating a missing manifest the same as
+// one with no entries.
+func indexManifest(m *Manifest) map[borges.RepositoryID]*ManifestEntry {
+	idx := map[borges.RepositoryID]*ManifestEntry{}
+	if m == nil {
+		return idx
+	}
+
+	for i := range m.Entries {
+		idx[m.Entries[i].RepositoryID] = &m.Entries[i]
+	}
+
+	return idx
+}
+
+func sameRefs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, hash := range a {
+		if b[name] != hash {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteManifest writes m to relPath in sink as JSON.
+func WriteManifest(ctx context.Context, sink Sink, relPath string, m *Manifest) (err error) {
+	w, err := sink.GetWriter(ctx, relPath)
+	if err != nil {
+		return err
+	}
+	defer ioutil.CheckClose(w, &err)
+
+	return json.NewEncoder(w).Encode(m)
+}
+
+// ReadManifest reads a Manifest previously written with WriteManifest from
+// relPath in sink.
+func ReadManifest(ctx context.Context, sink Sink, relPath string) (*Manifest, error) {
+	r, err := sink.GetReader(ctx, relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3Sink is a Sink backed by an S3-compatible object store.
+type S3Sink struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewS3Sink creates a S3Sink that stores objects under prefix in bucket.
+func NewS3Sink(client s3iface.S3API, bucket, prefix string) *S3Sink {
+	return &S3Sink{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (s *S3Sink) key(relPath string) string {
+	return path.Join(s.prefix, relPath)
+}
+
+// GetWriter implements Sink. The returned writer buffers its payload in
+// memory and uploads it as a single object on Close, since the S3 API has
+// no incremental PUT.
+func (s *S3Sink) GetWriter(ctx context.Context, relPath string) (io.WriteCloser, error) {
+	return &s3Writer{ctx: ctx, sink: s, key: s.key(relPath)}, nil
+}
+
+// GetReader implements Sink.
+func (s *S3Sink) GetReader(ctx context.Context, relPath string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(relPath)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Body, nil
+}
+
+type s3Writer struct {
+	ctx  context.Context
+	sink *S3Sink
+	key  string
+	buf  bytes.Buffer
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *s3Writer) Close() error {
+	_, err := w.sink.client.PutObjectWithContext(w.ctx, &s3.PutObjectInput{
+		Bucket: aws.String(w.sink.bucket),
+		Key:    aws.String(w.key),
+		Body:   bytes.NewReader(w.buf.Bytes()),
+	})
+
+	return err
+}
@@ -0,0 +1,33 @@
+// Package backup snapshots and restores the repositories of a
+// borges.Library as git bundles plus a manifest describing them, in the
+// spirit of Gitaly's backup package.
+package backup
+
+import (
+	"context"
+	"io"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrSkipped marks a repository that has no refs to back up. Backup
+	// leaves such a repository out of the manifest and carries on instead
+	// of returning this error.
+	ErrSkipped = errors.NewKind("repository %s skipped: no refs to backup")
+	// ErrInvalidBundle is returned when a bundle read from a Sink does not
+	// start with a recognised git bundle header.
+	ErrInvalidBundle = errors.NewKind("invalid bundle header %q")
+)
+
+// Sink abstracts the storage backend a backup is written to and restored
+// from. Implementations only need to resolve a relative path into a reader
+// or writer; bundle framing and manifest bookkeeping live in Strategy.
+type Sink interface {
+	// GetWriter returns a writer for relPath, creating any intermediate
+	// directories as needed. The caller must Close it to make the data
+	// durable.
+	GetWriter(ctx context.Context, relPath string) (io.WriteCloser, error)
+	// GetReader returns a reader for relPath.
+	GetReader(ctx context.Context, relPath string) (io.ReadCloser, error)
+}
@@ -0,0 +1,157 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"path"
+	"time"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4/utils/ioutil"
+)
+
+// Strategy backs up and restores every repository of a borges.Library as
+// one git bundle per repository, indexed by a Manifest.
+type Strategy struct {
+	Sink      Sink
+	LibraryID borges.LibraryID
+}
+
+// NewStrategy creates a Strategy that stores bundles in sink, namespaced
+// under libraryID.
+func NewStrategy(sink Sink, libraryID borges.LibraryID) *Strategy {
+	return &Strategy{Sink: sink, LibraryID: libraryID}
+}
+
+// Backup walks every repository in lib and writes one bundle per
+// repository to the Sink, skipping repositories with no refs. If prev is
+// non-nil, a repository whose refs are unchanged since prev keeps its
+// existing bundle path instead of being rewritten, making the backup
+// incremental.
+func (s *Strategy) Backup(ctx context.Context, lib borges.Library, prev *Manifest) (*Manifest, error) {
+	iter, err := lib.Repositories(borges.ReadOnlyMode)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	prevByID := indexManifest(prev)
+
+	manifest := &Manifest{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		repo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := s.backupRepository(ctx, repo, prevByID[repo.ID()])
+		if err != nil {
+			if ErrSkipped.Is(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		manifest.Entries = append(manifest.Entries, *entry)
+	}
+
+	return manifest, nil
+}
+
+func (s *Strategy) backupRepository(
+	ctx context.Context,
+	repo borges.Repository,
+	prevEntry *ManifestEntry,
+) (*ManifestEntry, error) {
+	refs, head, err := collectRefs(repo.R())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(refs) == 0 {
+		return nil, ErrSkipped.New(repo.ID())
+	}
+
+	if prevEntry != nil && sameRefs(prevEntry.Refs, refs) {
+		unchanged := *prevEntry
+		return &unchanged, nil
+	}
+
+	relPath := bundlePath(s.LibraryID, repo.LocationID(), repo.ID())
+	if err := s.writeRepositoryBundle(ctx, repo, refs, relPath); err != nil {
+		return nil, err
+	}
+
+	return &ManifestEntry{
+		RepositoryID: repo.ID(),
+		LocationID:   repo.LocationID(),
+		LibraryID:    s.LibraryID,
+		Refs:         refs,
+		Head:         head,
+		BundlePath:   relPath,
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+func (s *Strategy) writeRepositoryBundle(
+	ctx context.Context,
+	repo borges.Repository,
+	refs map[string]string,
+	relPath string,
+) (err error) {
+	w, err := s.Sink.GetWriter(ctx, relPath)
+	if err != nil {
+		return err
+	}
+	defer ioutil.CheckClose(w, &err)
+
+	return writeBundle(repo.R(), refs, w)
+}
+
+// Restore reverses Backup: for every entry in manifest it initialises the
+// repository in lib and fetches its refs and objects from the entry's
+// bundle in the Sink.
+func (s *Strategy) Restore(ctx context.Context, lib borges.Library, manifest *Manifest) error {
+	for _, entry := range manifest.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := s.restoreRepository(ctx, lib, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Strategy) restoreRepository(ctx context.Context, lib borges.Library, entry ManifestEntry) error {
+	repo, err := lib.GetOrInit(entry.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.Sink.GetReader(ctx, entry.BundlePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return readBundle(repo.R(), r)
+}
+
+// bundlePath returns the Sink-relative path a repository's bundle is
+// stored at, namespaced by library and location so two libraries sharing a
+// Sink never collide.
+func bundlePath(lib borges.LibraryID, loc borges.LocationID, id borges.RepositoryID) string {
+	return path.Join(string(lib), string(loc), string(id)+".bundle")
+}
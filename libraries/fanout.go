@@ -0,0 +1,151 @@
+package libraries
+
+import (
+	"context"
+	"sync"
+
+	"github.com/src-d/go-borges"
+)
+
+// libsSlice takes a snapshot of the sub-libraries so concurrent dispatch has a
+// stable, ordered view to work with.
+func (l *Libraries) libsSlice() []borges.Library {
+	libs := make([]borges.Library, 0, len(l.libs))
+	for _, lib := range l.libs {
+		libs = append(libs, lib)
+	}
+
+	return libs
+}
+
+// concurrency returns the configured fan-out width, defaulting to querying
+// every sub-library at once.
+func (l *Libraries) concurrency() int {
+	if l.opts.Concurrency <= 0 {
+		return len(l.libs)
+	}
+
+	return l.opts.Concurrency
+}
+
+// firstOf dispatches do against every lib in libs using a worker pool sized
+// by Options.Concurrency. As soon as one call reports found, the context
+// shared by the remaining workers is cancelled and that result is returned.
+func firstOf(
+	ctx context.Context,
+	libs []borges.Library,
+	concurrency int,
+	do func(ctx context.Context, lib borges.Library) (val interface{}, found bool, err error),
+) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		val   interface{}
+		found bool
+		err   error
+	}
+
+	results := make(chan result, len(libs))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for _, lib := range libs {
+		wg.Add(1)
+		go func(lib borges.Library) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results <- result{err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			val, found, err := do(ctx, lib)
+			results <- result{val: val, found: found, err: err}
+		}(lib)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+
+			continue
+		}
+
+		if res.found {
+			cancel()
+			return res.val, nil
+		}
+	}
+
+	return nil, firstErr
+}
+
+// allOf dispatches do against every lib in libs using a worker pool sized by
+// concurrency and returns their results in the same order as libs. It stops
+// as soon as any call errors, cancelling the shared context for the rest.
+func allOf(
+	ctx context.Context,
+	libs []borges.Library,
+	concurrency int,
+	do func(ctx context.Context, lib borges.Library) (interface{}, error),
+) ([]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		idx int
+		val interface{}
+		err error
+	}
+
+	results := make(chan result, len(libs))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, lib := range libs {
+		wg.Add(1)
+		go func(i int, lib borges.Library) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results <- result{idx: i, err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			val, err := do(ctx, lib)
+			results <- result{idx: i, val: val, err: err}
+		}(i, lib)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make([]interface{}, len(libs))
+	for res := range results {
+		if res.err != nil {
+			cancel()
+			return nil, res.err
+		}
+
+		out[res.idx] = res.val
+	}
+
+	return out, nil
+}
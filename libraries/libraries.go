@@ -21,7 +21,7 @@ type FilterLibraryFunc func(borges.Library) (bool, error)
 
 // RepositoryIterFunc stands for a function returning a
 // borges.RepositoryIterator which iters in a certain order.
-type RepositoryIterFunc func(*Libraries, borges.Mode) (borges.RepositoryIterator, error)
+type RepositoryIterFunc func(context.Context, *Libraries, borges.Mode) (borges.RepositoryIterator, error)
 
 // Options hold configuration options for a Libraries.
 type Options struct {
@@ -30,6 +30,10 @@ type Options struct {
 	// returned. A 0 value sets a default value of 60 seconds.
 	Timeout             time.Duration
 	RepositoryIterOrder RepositoryIterFunc
+	// Concurrency sets the maximum number of sub-libraries queried at the
+	// same time by Get, Has, Location, Locations, and FilteredLibraries.
+	// A 0 value queries all the sub-libraries at once.
+	Concurrency int
 }
 
 // Libraries is an implementation to aggregate borges.Library in just one instance.
@@ -92,29 +96,41 @@ func (l *Libraries) Init(borges.RepositoryID) (borges.Repository, error) {
 
 // Get implements the Library interface.
 func (l *Libraries) Get(id borges.RepositoryID, mode borges.Mode) (borges.Repository, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.opts.Timeout)
+	return l.GetContext(context.Background(), id, mode)
+}
+
+// GetContext implements the Library interface.
+func (l *Libraries) GetContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+	mode borges.Mode,
+) (borges.Repository, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.Timeout)
 	defer cancel()
 
-	for _, lib := range l.libs {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+	val, err := firstOf(ctx, l.libsSlice(), l.concurrency(),
+		func(ctx context.Context, lib borges.Library) (interface{}, bool, error) {
+			r, err := lib.GetContext(ctx, id, mode)
+			if err != nil {
+				if borges.ErrRepositoryNotExists.Is(err) {
+					return nil, false, nil
+				}
 
-		r, err := lib.Get(id, mode)
-		if err != nil {
-			if borges.ErrRepositoryNotExists.Is(err) {
-				continue
+				return nil, false, err
 			}
 
-			return nil, err
-		}
+			return r, true, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		return r, nil
+	if val == nil {
+		return nil, borges.ErrRepositoryNotExists.New(id)
 	}
 
-	return nil, borges.ErrRepositoryNotExists.New(id)
+	return val.(borges.Repository), nil
 }
 
 // GetOrInit implements the Library interface.
@@ -124,80 +140,124 @@ func (l *Libraries) GetOrInit(borges.RepositoryID) (borges.Repository, error) {
 
 // Has implements the Library interface.
 func (l *Libraries) Has(id borges.RepositoryID) (bool, borges.LibraryID, borges.LocationID, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.opts.Timeout)
+	return l.HasContext(context.Background(), id)
+}
+
+// hasResult carries the sub-library along with its Has result, since the
+// libID reported by HasContext belongs to the sub-library that answered.
+type hasResult struct {
+	libID borges.LibraryID
+	locID borges.LocationID
+}
+
+// HasContext implements the Library interface.
+func (l *Libraries) HasContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+) (bool, borges.LibraryID, borges.LocationID, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.Timeout)
 	defer cancel()
 
-	for _, lib := range l.libs {
-		select {
-		case <-ctx.Done():
-			return false, "", "", ctx.Err()
-		default:
-		}
+	val, err := firstOf(ctx, l.libsSlice(), l.concurrency(),
+		func(ctx context.Context, lib borges.Library) (interface{}, bool, error) {
+			has, libID, locID, err := lib.HasContext(ctx, id)
+			if err != nil {
+				return nil, false, err
+			}
 
-		has, libID, locID, err := lib.Has(id)
-		if err != nil {
-			return false, "", "", err
-		}
+			if !has {
+				return nil, false, nil
+			}
 
-		if has {
-			return has, libID, locID, nil
-		}
+			return hasResult{libID: libID, locID: locID}, true, nil
+		},
+	)
+	if err != nil {
+		return false, "", "", err
 	}
 
-	return false, "", "", nil
+	if val == nil {
+		return false, "", "", nil
+	}
+
+	res := val.(hasResult)
+	return true, res.libID, res.locID, nil
 }
 
 // Repositories implements the Library interface.
 func (l *Libraries) Repositories(mode borges.Mode) (borges.RepositoryIterator, error) {
-	return l.opts.RepositoryIterOrder(l, mode)
+	return l.RepositoriesContext(context.Background(), mode)
+}
+
+// RepositoriesContext implements the Library interface.
+func (l *Libraries) RepositoriesContext(
+	ctx context.Context,
+	mode borges.Mode,
+) (borges.RepositoryIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.opts.RepositoryIterOrder(ctx, l, mode)
 }
 
 // Location implements the Library interface.
 func (l *Libraries) Location(id borges.LocationID) (borges.Location, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.opts.Timeout)
+	return l.LocationContext(context.Background(), id)
+}
+
+// LocationContext implements the Library interface.
+func (l *Libraries) LocationContext(ctx context.Context, id borges.LocationID) (borges.Location, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.Timeout)
 	defer cancel()
 
-	for _, lib := range l.libs {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+	val, err := firstOf(ctx, l.libsSlice(), l.concurrency(),
+		func(ctx context.Context, lib borges.Library) (interface{}, bool, error) {
+			loc, err := lib.LocationContext(ctx, id)
+			if err != nil {
+				if borges.ErrLocationNotExists.Is(err) {
+					return nil, false, nil
+				}
 
-		loc, err := lib.Location(id)
-		if err != nil {
-			if borges.ErrLocationNotExists.Is(err) {
-				continue
+				return nil, false, err
 			}
 
-			return nil, err
-		}
+			return loc, true, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		return loc, nil
+	if val == nil {
+		return nil, borges.ErrLocationNotExists.New(id)
 	}
 
-	return nil, borges.ErrLocationNotExists.New(id)
+	return val.(borges.Location), nil
 }
 
 // Locations implements the Library interface.
 func (l *Libraries) Locations() (borges.LocationIterator, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.opts.Timeout)
-	defer cancel()
+	return l.LocationsContext(context.Background())
+}
 
-	var locations []borges.LocationIterator
-	for _, lib := range l.libs {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+// LocationsContext implements the Library interface.
+func (l *Libraries) LocationsContext(ctx context.Context) (borges.LocationIterator, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.Timeout)
+	defer cancel()
 
-		locs, err := lib.Locations()
-		if err != nil {
-			return nil, err
-		}
+	vals, err := allOf(ctx, l.libsSlice(), l.concurrency(),
+		func(ctx context.Context, lib borges.Library) (interface{}, error) {
+			return lib.LocationsContext(ctx)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		locations = append(locations, locs)
+	locations := make([]borges.LocationIterator, len(vals))
+	for i, v := range vals {
+		locations[i] = v.(borges.LocationIterator)
 	}
 
 	return MergeLocationIterators(locations), nil
@@ -235,21 +295,20 @@ func (l *Libraries) libraries(filter FilterLibraryFunc) ([]borges.Library, error
 	ctx, cancel := context.WithTimeout(context.Background(), l.opts.Timeout)
 	defer cancel()
 
-	libs := make([]borges.Library, 0, len(l.libs))
-	for _, lib := range l.libs {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		ok, err := filter(lib)
-		if err != nil {
-			return nil, err
-		}
+	candidates := l.libsSlice()
+	vals, err := allOf(ctx, candidates, l.concurrency(),
+		func(ctx context.Context, lib borges.Library) (interface{}, error) {
+			return filter(lib)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		if ok {
-			libs = append(libs, lib)
+	libs := make([]borges.Library, 0, len(candidates))
+	for i, ok := range vals {
+		if ok.(bool) {
+			libs = append(libs, candidates[i])
 		}
 	}
 
@@ -0,0 +1,191 @@
+package libraries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	borges "github.com/src-d/go-borges"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLibrary is a borges.Library that sleeps for a fixed duration before
+// answering, used to exercise the concurrent fan-out in Libraries.
+type mockLibrary struct {
+	id      borges.LibraryID
+	latency time.Duration
+	has     bool
+}
+
+func (m *mockLibrary) ID() borges.LibraryID { return m.id }
+
+func (m *mockLibrary) Init(borges.RepositoryID) (borges.Repository, error) {
+	return nil, borges.ErrNotImplemented.New()
+}
+
+func (m *mockLibrary) GetOrInit(borges.RepositoryID) (borges.Repository, error) {
+	return nil, borges.ErrNotImplemented.New()
+}
+
+func (m *mockLibrary) Get(id borges.RepositoryID, mode borges.Mode) (borges.Repository, error) {
+	return m.GetContext(context.Background(), id, mode)
+}
+
+func (m *mockLibrary) GetContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+	mode borges.Mode,
+) (borges.Repository, error) {
+	if err := sleep(ctx, m.latency); err != nil {
+		return nil, err
+	}
+
+	if !m.has {
+		return nil, borges.ErrRepositoryNotExists.New(id)
+	}
+
+	return &mockRepository{}, nil
+}
+
+// mockRepository is the non-nil borges.Repository a mockLibrary returns
+// when it has the requested repository.
+type mockRepository struct{ borges.Repository }
+
+func (m *mockLibrary) Has(id borges.RepositoryID) (bool, borges.LibraryID, borges.LocationID, error) {
+	return m.HasContext(context.Background(), id)
+}
+
+func (m *mockLibrary) HasContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+) (bool, borges.LibraryID, borges.LocationID, error) {
+	if err := sleep(ctx, m.latency); err != nil {
+		return false, "", "", err
+	}
+
+	if !m.has {
+		return false, "", "", nil
+	}
+
+	return true, m.id, borges.LocationID("loc"), nil
+}
+
+func (m *mockLibrary) Repositories(mode borges.Mode) (borges.RepositoryIterator, error) {
+	return nil, borges.ErrNotImplemented.New()
+}
+
+func (m *mockLibrary) RepositoriesContext(
+	ctx context.Context,
+	mode borges.Mode,
+) (borges.RepositoryIterator, error) {
+	return nil, borges.ErrNotImplemented.New()
+}
+
+func (m *mockLibrary) Location(id borges.LocationID) (borges.Location, error) {
+	return m.LocationContext(context.Background(), id)
+}
+
+func (m *mockLibrary) LocationContext(ctx context.Context, id borges.LocationID) (borges.Location, error) {
+	if err := sleep(ctx, m.latency); err != nil {
+		return nil, err
+	}
+
+	return nil, borges.ErrLocationNotExists.New(id)
+}
+
+func (m *mockLibrary) Locations() (borges.LocationIterator, error) {
+	return m.LocationsContext(context.Background())
+}
+
+func (m *mockLibrary) LocationsContext(ctx context.Context) (borges.LocationIterator, error) {
+	if err := sleep(ctx, m.latency); err != nil {
+		return nil, err
+	}
+
+	return nil, borges.ErrNotImplemented.New()
+}
+
+func (m *mockLibrary) Library(id borges.LibraryID) (borges.Library, error) {
+	return nil, borges.ErrLibraryNotExists.New(id)
+}
+
+func (m *mockLibrary) Libraries() (borges.LibraryIterator, error) {
+	return nil, borges.ErrNotImplemented.New()
+}
+
+// sleep blocks for d or returns ctx.Err() if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestLibrariesGetConcurrency(t *testing.T) {
+	require := require.New(t)
+
+	const latency = 100 * time.Millisecond
+	l := New(&Options{Concurrency: 4})
+	for i := 0; i < 4; i++ {
+		lib := &mockLibrary{
+			id:      borges.LibraryID(string(rune('a' + i))),
+			latency: latency,
+			has:     i == 3,
+		}
+		require.NoError(l.Add(lib))
+	}
+
+	start := time.Now()
+	_, err := l.Get("github.com/foo/bar", borges.RWMode)
+	elapsed := time.Since(start)
+
+	require.NoError(err)
+	require.Less(elapsed, 2*latency, "Get should run sub-libraries concurrently, not sequentially")
+}
+
+func TestLibrariesLocationConcurrency(t *testing.T) {
+	require := require.New(t)
+
+	const latency = 100 * time.Millisecond
+	l := New(&Options{Concurrency: 4})
+	for i := 0; i < 4; i++ {
+		lib := &mockLibrary{
+			id:      borges.LibraryID(string(rune('a' + i))),
+			latency: latency,
+		}
+		require.NoError(l.Add(lib))
+	}
+
+	start := time.Now()
+	_, err := l.Location("loc")
+	elapsed := time.Since(start)
+
+	require.True(borges.ErrLocationNotExists.Is(err))
+	require.Less(elapsed, 2*latency, "Location should run sub-libraries concurrently, not sequentially")
+}
+
+func TestLibrariesHasConcurrency(t *testing.T) {
+	require := require.New(t)
+
+	const latency = 100 * time.Millisecond
+	l := New(&Options{Concurrency: 4})
+	for i := 0; i < 4; i++ {
+		lib := &mockLibrary{
+			id:      borges.LibraryID(string(rune('a' + i))),
+			latency: latency,
+			has:     i == 3,
+		}
+		require.NoError(l.Add(lib))
+	}
+
+	start := time.Now()
+	has, libID, _, err := l.Has("github.com/foo/bar")
+	elapsed := time.Since(start)
+
+	require.NoError(err)
+	require.True(has)
+	require.Equal(borges.LibraryID("d"), libID)
+	require.Less(elapsed, 2*latency, "Has should run sub-libraries concurrently, not sequentially")
+}
@@ -1,6 +1,7 @@
 package borges
 
 import (
+	"context"
 	"path"
 	"strings"
 
@@ -15,17 +16,58 @@ var (
 	ErrLocationNotExists   = errors.NewKind("location %s not exists")
 	ErrRepositoryExists    = errors.NewKind("repository %s already exists")
 	ErrRepositoryNotExists = errors.NewKind("repository %s not exists")
+	ErrLibraryNotExists    = errors.NewKind("library %s not exists")
 )
 
+// Library is a collection of Locations, each holding Repositories. An
+// aggregator like libraries.Libraries implements it over several
+// sub-libraries, in which case the LibraryID results returned by Has and
+// HasContext identify which sub-library actually answered.
 type Library interface {
 	GetOrInit(RepositoryID) (Repository, error)
 	Init(RepositoryID) (Repository, error)
-	Has(RepositoryID) (bool, LocationID, error)
+	Has(RepositoryID) (bool, LibraryID, LocationID, error)
 	Get(RepositoryID, Mode) (Repository, error)
 	Repositories(Mode) (RepositoryIterator, error)
 
 	Location(id LocationID) (Location, error)
-	//Locations() (LocationIter, error)
+	Locations() (LocationIterator, error)
+
+	// GetContext, HasContext, LocationContext, RepositoriesContext, and
+	// LocationsContext are the context-aware counterparts of Get, Has,
+	// Location, Repositories, and Locations. Implementations must stop
+	// and return ctx.Err() as soon as ctx is done, instead of running to
+	// completion.
+	GetContext(ctx context.Context, id RepositoryID, mode Mode) (Repository, error)
+	HasContext(ctx context.Context, id RepositoryID) (bool, LibraryID, LocationID, error)
+	LocationContext(ctx context.Context, id LocationID) (Location, error)
+	RepositoriesContext(ctx context.Context, mode Mode) (RepositoryIterator, error)
+	LocationsContext(ctx context.Context) (LocationIterator, error)
+}
+
+// LibraryID identifies a Library, unique among the sub-libraries of
+// whichever aggregator holds it.
+type LibraryID string
+
+// RepositoryIterator iterates over a sequence of Repository, returning
+// io.EOF from Next once exhausted.
+type RepositoryIterator interface {
+	Next() (Repository, error)
+	Close()
+}
+
+// LocationIterator iterates over a sequence of Location, returning io.EOF
+// from Next once exhausted.
+type LocationIterator interface {
+	Next() (Location, error)
+	Close()
+}
+
+// LibraryIterator iterates over a sequence of Library, returning io.EOF
+// from Next once exhausted.
+type LibraryIterator interface {
+	Next() (Library, error)
+	Close()
 }
 
 type Mode int
@@ -49,6 +91,13 @@ type Location interface {
 	Has(RepositoryID) (bool, error)
 	Get(RepositoryID, Mode) (Repository, error)
 	Repositories(Mode) (RepositoryIterator, error)
+
+	// GetContext, HasContext, and RepositoriesContext are the
+	// context-aware counterparts of Get, Has, and Repositories. See
+	// Library for the cancellation contract they must honour.
+	GetContext(ctx context.Context, id RepositoryID, mode Mode) (Repository, error)
+	HasContext(ctx context.Context, id RepositoryID) (bool, error)
+	RepositoriesContext(ctx context.Context, mode Mode) (RepositoryIterator, error)
 }
 
 type RepositoryID string
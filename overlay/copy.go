@@ -0,0 +1,41 @@
+package overlay
+
+import (
+	"context"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// copyStorer copies every object and reference from src into dst, giving
+// dst a full, independent copy of src's storer.
+func copyStorer(ctx context.Context, src, dst borges.Repository) error {
+	objects, err := src.R().Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return err
+	}
+	defer objects.Close()
+
+	err = objects.ForEach(func(obj plumbing.EncodedObject) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, err := dst.R().Storer.SetEncodedObject(obj)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	refs, err := src.R().References()
+	if err != nil {
+		return err
+	}
+	defer refs.Close()
+
+	return refs.ForEach(func(ref *plumbing.Reference) error {
+		return dst.R().Storer.SetReference(ref)
+	})
+}
@@ -0,0 +1,75 @@
+package overlay
+
+import (
+	"io"
+
+	"github.com/src-d/go-borges"
+)
+
+// unionIterator iterates the repositories of an overlay Location, merging
+// upper and lower lazily: each Next call pulls from upper until it is
+// exhausted, then falls back to lower, pulling only as many repositories
+// as it takes to find the next one to return.
+type unionIterator struct {
+	upper, lower borges.RepositoryIterator
+	whiteouts    map[borges.RepositoryID]struct{}
+	seen         map[borges.RepositoryID]struct{}
+
+	upperDone bool
+}
+
+func newUnionIterator(
+	upper, lower borges.RepositoryIterator,
+	whiteouts map[borges.RepositoryID]struct{},
+) (*unionIterator, error) {
+	return &unionIterator{
+		upper:     upper,
+		lower:     lower,
+		whiteouts: whiteouts,
+		seen:      map[borges.RepositoryID]struct{}{},
+	}, nil
+}
+
+// Next implements the borges.RepositoryIterator interface. It yields every
+// repository of upper first, then every repository of lower whose ID
+// wasn't already yielded from upper and isn't whited out. Duplicates and
+// whited-out repositories are still pulled from lower, and so opened by
+// it, but are discarded instead of being returned.
+func (i *unionIterator) Next() (borges.Repository, error) {
+	if !i.upperDone {
+		r, err := i.upper.Next()
+		switch err {
+		case nil:
+			i.seen[r.ID()] = struct{}{}
+			return r, nil
+		case io.EOF:
+			i.upperDone = true
+		default:
+			return nil, err
+		}
+	}
+
+	for {
+		r, err := i.lower.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := i.seen[r.ID()]; ok {
+			continue
+		}
+
+		if _, white := i.whiteouts[r.ID()]; white {
+			continue
+		}
+
+		i.seen[r.ID()] = struct{}{}
+		return r, nil
+	}
+}
+
+// Close implements the borges.RepositoryIterator interface.
+func (i *unionIterator) Close() {
+	i.upper.Close()
+	i.lower.Close()
+}
@@ -0,0 +1,103 @@
+package overlay
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/src-d/go-borges"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// mockRepository is a borges.Repository whose only meaningful method is
+// ID, enough to exercise unionIterator's deduplication.
+type mockRepository struct {
+	id borges.RepositoryID
+}
+
+func (m *mockRepository) ID() borges.RepositoryID       { return m.id }
+func (m *mockRepository) LocationID() borges.LocationID { return "" }
+func (m *mockRepository) Mode() borges.Mode             { return borges.ReadOnlyMode }
+func (m *mockRepository) Commit() error                 { return borges.ErrNotImplemented.New() }
+func (m *mockRepository) Rollback() error                { return borges.ErrNotImplemented.New() }
+func (m *mockRepository) R() *git.Repository            { return nil }
+
+// mockRepositoryIterator yields items in order, returning err (when set)
+// once items is exhausted instead of io.EOF, simulating a backend
+// that fails partway through a scan.
+type mockRepositoryIterator struct {
+	items []borges.Repository
+	pos   int
+	err   error
+}
+
+func (i *mockRepositoryIterator) Next() (borges.Repository, error) {
+	if i.pos >= len(i.items) {
+		if i.err != nil {
+			return nil, i.err
+		}
+		return nil, io.EOF
+	}
+
+	r := i.items[i.pos]
+	i.pos++
+	return r, nil
+}
+
+func (i *mockRepositoryIterator) Close() {}
+
+func TestNewUnionIteratorPropagatesError(t *testing.T) {
+	require := require.New(t)
+
+	wantErr := errors.New("backend unavailable")
+	upper := &mockRepositoryIterator{
+		items: []borges.Repository{&mockRepository{id: "a"}},
+		err:   wantErr,
+	}
+	lower := &mockRepositoryIterator{
+		items: []borges.Repository{&mockRepository{id: "b"}},
+	}
+
+	iter, err := newUnionIterator(upper, lower, nil)
+	require.NoError(err)
+
+	r, err := iter.Next()
+	require.NoError(err)
+	require.Equal(borges.RepositoryID("a"), r.ID())
+
+	_, err = iter.Next()
+	require.Equal(wantErr, err)
+}
+
+func TestNewUnionIteratorDeduplicatesAndExcludesWhiteouts(t *testing.T) {
+	require := require.New(t)
+
+	upper := &mockRepositoryIterator{
+		items: []borges.Repository{&mockRepository{id: "a"}},
+	}
+	lower := &mockRepositoryIterator{
+		items: []borges.Repository{
+			&mockRepository{id: "a"},
+			&mockRepository{id: "b"},
+			&mockRepository{id: "c"},
+		},
+	}
+
+	whiteouts := map[borges.RepositoryID]struct{}{"c": {}}
+
+	iter, err := newUnionIterator(upper, lower, whiteouts)
+	require.NoError(err)
+
+	var ids []borges.RepositoryID
+	for {
+		r, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		ids = append(ids, r.ID())
+	}
+
+	require.Equal([]borges.RepositoryID{"a", "b"}, ids)
+}
@@ -0,0 +1,237 @@
+// Package overlay provides a borges.Location that layers a writable upper
+// location over a read-only lower one, copying a repository's storer up to
+// the upper location the first time it is opened for write. This mirrors
+// the overlay-mount idea from containers/buildah, letting callers run
+// experiments against a large shared location without mutating it.
+package overlay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/src-d/go-borges"
+)
+
+var _ borges.Location = (*Location)(nil)
+
+// Location composes a read-only lower borges.Location with a writable
+// upper one. Reads are served from the upper location when present,
+// falling back to the lower one; writes always land in the upper
+// location, copying the repository up from the lower one on first write.
+type Location struct {
+	id    borges.LocationID
+	lower borges.Location
+	upper borges.Location
+
+	mu        sync.RWMutex
+	whiteouts map[borges.RepositoryID]struct{}
+
+	// failedCopies remembers, by RepositoryID, the error a copy-up left
+	// half-finished in the upper location. borges.Location has no way to
+	// remove what Init already created there, so a failed copy-up cannot
+	// be retried from scratch; instead every later Has/Get on that id
+	// keeps returning this error instead of silently serving the
+	// incomplete upper copy as if it were whole.
+	failedCopies map[borges.RepositoryID]error
+}
+
+// NewLocation creates an overlay Location with id, reading from lower when
+// upper has no copy of a repository and writing only to upper.
+func NewLocation(id borges.LocationID, lower, upper borges.Location) *Location {
+	return &Location{
+		id:           id,
+		lower:        lower,
+		upper:        upper,
+		whiteouts:    map[borges.RepositoryID]struct{}{},
+		failedCopies: map[borges.RepositoryID]error{},
+	}
+}
+
+// ID implements the borges.Location interface.
+func (l *Location) ID() borges.LocationID {
+	return l.id
+}
+
+// Has implements the borges.Location interface.
+func (l *Location) Has(id borges.RepositoryID) (bool, error) {
+	return l.HasContext(context.Background(), id)
+}
+
+// HasContext implements the borges.Location interface.
+func (l *Location) HasContext(ctx context.Context, id borges.RepositoryID) (bool, error) {
+	if l.whited(id) {
+		return false, nil
+	}
+
+	if err := l.copyFailure(id); err != nil {
+		return false, err
+	}
+
+	has, err := l.upper.HasContext(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if has {
+		return true, nil
+	}
+
+	return l.lower.HasContext(ctx, id)
+}
+
+// Get implements the borges.Location interface.
+func (l *Location) Get(id borges.RepositoryID, mode borges.Mode) (borges.Repository, error) {
+	return l.GetContext(context.Background(), id, mode)
+}
+
+// GetContext implements the borges.Location interface. A repository opened
+// in borges.ReadOnlyMode resolves to the upper copy if one exists,
+// otherwise the lower one. Any writable mode triggers a copy-up from the
+// lower location the first time it is requested.
+func (l *Location) GetContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+	mode borges.Mode,
+) (borges.Repository, error) {
+	if l.whited(id) {
+		return nil, borges.ErrRepositoryNotExists.New(id)
+	}
+
+	if err := l.copyFailure(id); err != nil {
+		return nil, err
+	}
+
+	hasUpper, err := l.upper.HasContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == borges.ReadOnlyMode {
+		if hasUpper {
+			return l.upper.GetContext(ctx, id, mode)
+		}
+
+		return l.lower.GetContext(ctx, id, mode)
+	}
+
+	if !hasUpper {
+		if err := l.copyUp(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.upper.GetContext(ctx, id, mode)
+}
+
+// Init implements the borges.Location interface. It always creates the
+// repository in the upper location, clearing any whiteout previously set
+// on id.
+func (l *Location) Init(id borges.RepositoryID) (borges.Repository, error) {
+	l.clearWhiteout(id)
+	return l.upper.Init(id)
+}
+
+// GetOrInit implements the borges.Location interface.
+func (l *Location) GetOrInit(id borges.RepositoryID) (borges.Repository, error) {
+	has, err := l.Has(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if has {
+		return l.Get(id, borges.RWMode)
+	}
+
+	return l.Init(id)
+}
+
+// Repositories implements the borges.Location interface.
+func (l *Location) Repositories(mode borges.Mode) (borges.RepositoryIterator, error) {
+	return l.RepositoriesContext(context.Background(), mode)
+}
+
+// RepositoriesContext implements the borges.Location interface, returning
+// the union of both layers deduplicated by RepositoryID, upper wins, and
+// excluding anything whited out.
+func (l *Location) RepositoriesContext(
+	ctx context.Context,
+	mode borges.Mode,
+) (borges.RepositoryIterator, error) {
+	upperIter, err := l.upper.RepositoriesContext(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	lowerIter, err := l.lower.RepositoriesContext(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return newUnionIterator(upperIter, lowerIter, l.whiteouts)
+}
+
+// Whiteout marks id as deleted in this overlay: it stops appearing as
+// coming from the lower location, even though the lower filesystem is
+// left untouched.
+func (l *Location) Whiteout(id borges.RepositoryID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.whiteouts[id] = struct{}{}
+	return nil
+}
+
+func (l *Location) whited(id borges.RepositoryID) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.whiteouts[id]
+	return ok
+}
+
+func (l *Location) clearWhiteout(id borges.RepositoryID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.whiteouts, id)
+}
+
+// copyFailure returns the error a previous copy-up of id left behind, if
+// any.
+func (l *Location) copyFailure(id borges.RepositoryID) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.failedCopies[id]
+}
+
+func (l *Location) copyUp(ctx context.Context, id borges.RepositoryID) error {
+	lowerRepo, err := l.lower.GetContext(ctx, id, borges.ReadOnlyMode)
+	if err != nil {
+		return err
+	}
+
+	upperRepo, err := l.upper.Init(id)
+	if err != nil {
+		return err
+	}
+
+	if err := copyStorer(ctx, lowerRepo, upperRepo); err != nil {
+		// upperRepo.Rollback discards any pending transactional writes,
+		// but borges.Location exposes no way to undo Init itself, so the
+		// half-copied repository stays in the upper location. Remember
+		// the failure instead, so every later Has/Get on id keeps
+		// reporting it rather than silently serving the incomplete copy.
+		upperRepo.Rollback()
+
+		l.mu.Lock()
+		l.failedCopies[id] = err
+		l.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
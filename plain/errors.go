@@ -0,0 +1,21 @@
+package plain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitConflictError is returned by Repository.Commit when one or more
+// of the subsystems selected by TransactionalOptions changed in the
+// parent storer after the transaction was opened and, for references,
+// RefConflictPolicy could not resolve the difference. Refs names the
+// conflicting references, or the literal "config", "shallow", or "index"
+// for a conflict in one of those subsystems. Callers can inspect it,
+// reload fresh state, and retry.
+type CommitConflictError struct {
+	Refs []string
+}
+
+func (e *CommitConflictError) Error() string {
+	return fmt.Sprintf("commit conflict on: %s", strings.Join(e.Refs, ", "))
+}
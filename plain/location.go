@@ -0,0 +1,201 @@
+package plain
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+)
+
+// LocationOptions hold the configuration for a Location.
+type LocationOptions struct {
+	// Performance, when true, opens each Repository's filesystem storage
+	// with exclusive access and keeps its file descriptors open across
+	// operations, trading safety under concurrent external access to the
+	// same repository for speed.
+	Performance bool
+	// Cache is the object cache new Repository storers are created with.
+	// A nil value defaults to cache.NewObjectLRUDefault().
+	Cache cache.Object
+	// Transactional enables transactional mode: writes made to a
+	// Repository opened in borges.RWMode land in a temporal storer and
+	// only reach this Location's filesystem once Repository.Commit is
+	// called.
+	Transactional bool
+	// TemporalFilesystem is where the temporal storer of a transactional
+	// Repository is rooted. Required when Transactional is true.
+	TemporalFilesystem billy.Filesystem
+	// TransactionalOptions selects which storage subsystems of a
+	// transactional Repository are checked for conflicts on Commit, and
+	// how those conflicts are resolved. Ignored unless Transactional is
+	// true.
+	TransactionalOptions TransactionalOptions
+}
+
+// Location is a borges.Location whose repositories are plain git
+// repositories stored as subdirectories of a billy.Filesystem, one per
+// RepositoryID.
+type Location struct {
+	id   borges.LocationID
+	fs   billy.Filesystem
+	opts *LocationOptions
+}
+
+var _ borges.Location = (*Location)(nil)
+
+// NewLocation creates a Location with id, rooted at fs. A nil opts is
+// equivalent to an empty LocationOptions.
+func NewLocation(id borges.LocationID, fs billy.Filesystem, opts *LocationOptions) *Location {
+	if opts == nil {
+		opts = &LocationOptions{}
+	}
+
+	return &Location{id: id, fs: fs, opts: opts}
+}
+
+// ID implements the borges.Location interface.
+func (l *Location) ID() borges.LocationID {
+	return l.id
+}
+
+// RepositoryPath returns the path, relative to l's filesystem, a
+// repository with the given id is stored at.
+func (l *Location) RepositoryPath(id borges.RepositoryID) string {
+	return string(id)
+}
+
+// Has implements the borges.Location interface.
+func (l *Location) Has(id borges.RepositoryID) (bool, error) {
+	return l.HasContext(context.Background(), id)
+}
+
+// HasContext implements the borges.Location interface.
+func (l *Location) HasContext(ctx context.Context, id borges.RepositoryID) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, err := l.fs.Stat(l.RepositoryPath(id))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Get implements the borges.Location interface.
+func (l *Location) Get(id borges.RepositoryID, mode borges.Mode) (borges.Repository, error) {
+	return l.GetContext(context.Background(), id, mode)
+}
+
+// GetContext implements the borges.Location interface.
+func (l *Location) GetContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+	mode borges.Mode,
+) (borges.Repository, error) {
+	has, err := l.HasContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !has {
+		return nil, borges.ErrRepositoryNotExists.New(id)
+	}
+
+	return openRepository(l, id, mode)
+}
+
+// Init implements the borges.Location interface.
+func (l *Location) Init(id borges.RepositoryID) (borges.Repository, error) {
+	has, err := l.Has(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if has {
+		return nil, borges.ErrRepositoryExists.New(id)
+	}
+
+	return initRepository(l, id)
+}
+
+// GetOrInit implements the borges.Location interface.
+func (l *Location) GetOrInit(id borges.RepositoryID) (borges.Repository, error) {
+	has, err := l.Has(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if has {
+		return l.Get(id, borges.RWMode)
+	}
+
+	return l.Init(id)
+}
+
+// Repositories implements the borges.Location interface.
+func (l *Location) Repositories(mode borges.Mode) (borges.RepositoryIterator, error) {
+	return l.RepositoriesContext(context.Background(), mode)
+}
+
+// RepositoriesContext implements the borges.Location interface. The
+// returned iterator opens each repository lazily, as it is requested by
+// Next, so a context cancelled mid-iteration stops opening further
+// repositories instead of running to completion.
+func (l *Location) RepositoriesContext(
+	ctx context.Context,
+	mode borges.Mode,
+) (borges.RepositoryIterator, error) {
+	infos, err := l.fs.ReadDir("")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]borges.RepositoryID, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		ids = append(ids, borges.RepositoryID(info.Name()))
+	}
+
+	return &repositoryIterator{l: l, ctx: ctx, ids: ids, mode: mode}, nil
+}
+
+// repositoryIterator iterates the repositories of a Location, opening
+// each one lazily as Next is called.
+type repositoryIterator struct {
+	l    *Location
+	ctx  context.Context
+	ids  []borges.RepositoryID
+	mode borges.Mode
+	pos  int
+}
+
+// Next implements the borges.RepositoryIterator interface.
+func (i *repositoryIterator) Next() (borges.Repository, error) {
+	if err := i.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if i.pos >= len(i.ids) {
+		return nil, io.EOF
+	}
+
+	id := i.ids[i.pos]
+	i.pos++
+
+	return i.l.GetContext(i.ctx, id, i.mode)
+}
+
+// Close implements the borges.RepositoryIterator interface.
+func (i *repositoryIterator) Close() {}
@@ -1,14 +1,18 @@
 package plain
 
 import (
+	"reflect"
+
 	"github.com/src-d/go-borges"
 	"github.com/src-d/go-borges/util"
 
 	"gopkg.in/src-d/go-billy.v4"
 	butil "gopkg.in/src-d/go-billy.v4/util"
 	"gopkg.in/src-d/go-git.v4"
-	"gopkg.in/src-d/go-git.v4/config"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
 	"gopkg.in/src-d/go-git.v4/storage"
 	"gopkg.in/src-d/go-git.v4/storage/filesystem"
 	"gopkg.in/src-d/go-git.v4/storage/transactional"
@@ -23,11 +27,25 @@ type Repository struct {
 	temporalPath string
 	fs           billy.Filesystem
 
+	// parent, temp, and the baselines are only set in transactional mode:
+	// parent is the non-temporal storer writes are committed against,
+	// temp is the dedicated storer that receives the transaction's own
+	// writes (and so, unlike r.Storer, never reads through to parent), and
+	// each baseline is the value of its subsystem in parent when the
+	// transaction was opened, used by Commit to detect concurrent changes
+	// in whichever subsystems the Location's TransactionalOptions selects.
+	parent          storage.Storer
+	temp            storage.Storer
+	baseline        map[plumbing.ReferenceName]*plumbing.Reference
+	configBaseline  *gitconfig.Config
+	shallowBaseline []plumbing.Hash
+	indexBaseline   *index.Index
+
 	*git.Repository
 }
 
 func initRepository(l *Location, id borges.RepositoryID) (*Repository, error) {
-	s, fs, tempPath, err := repositoryStorer(l, id, borges.RWMode)
+	s, fs, tempPath, bl, err := repositoryStorer(l, id, borges.RWMode)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +55,7 @@ func initRepository(l *Location, id borges.RepositoryID) (*Repository, error) {
 		return nil, err
 	}
 
-	_, err = r.CreateRemote(&config.RemoteConfig{
+	_, err = r.CreateRemote(&gitconfig.RemoteConfig{
 		Name: "origin",
 		URLs: []string{id.String()},
 	})
@@ -47,18 +65,24 @@ func initRepository(l *Location, id borges.RepositoryID) (*Repository, error) {
 	}
 
 	return &Repository{
-		id:           id,
-		l:            l,
-		mode:         borges.RWMode,
-		temporalPath: tempPath,
-		fs:           fs,
-		Repository:   r,
+		id:              id,
+		l:               l,
+		mode:            borges.RWMode,
+		temporalPath:    tempPath,
+		fs:              fs,
+		parent:          bl.parent,
+		temp:            bl.temp,
+		baseline:        bl.refs,
+		configBaseline:  bl.config,
+		shallowBaseline: bl.shallow,
+		indexBaseline:   bl.index,
+		Repository:      r,
 	}, nil
 }
 
 // openRepository, is the basic operation of open a repository without any checking.
 func openRepository(l *Location, id borges.RepositoryID, mode borges.Mode) (*Repository, error) {
-	s, fs, tempPath, err := repositoryStorer(l, id, mode)
+	s, fs, tempPath, bl, err := repositoryStorer(l, id, mode)
 	if err != nil {
 		return nil, err
 	}
@@ -69,23 +93,41 @@ func openRepository(l *Location, id borges.RepositoryID, mode borges.Mode) (*Rep
 	}
 
 	return &Repository{
-		id:           id,
-		l:            l,
-		mode:         mode,
-		temporalPath: tempPath,
-		fs:           fs,
-		Repository:   r,
+		id:              id,
+		l:               l,
+		mode:            mode,
+		temporalPath:    tempPath,
+		fs:              fs,
+		parent:          bl.parent,
+		temp:            bl.temp,
+		baseline:        bl.refs,
+		configBaseline:  bl.config,
+		shallowBaseline: bl.shallow,
+		indexBaseline:   bl.index,
+		Repository:      r,
 	}, nil
 }
 
+// transactionBaseline bundles everything captured from parent when a
+// transaction is opened, so Commit can later detect which selected
+// subsystems changed concurrently.
+type transactionBaseline struct {
+	parent  storage.Storer
+	temp    storage.Storer
+	refs    map[plumbing.ReferenceName]*plumbing.Reference
+	config  *gitconfig.Config
+	shallow []plumbing.Hash
+	index   *index.Index
+}
+
 func repositoryStorer(
 	l *Location,
 	id borges.RepositoryID,
 	mode borges.Mode,
-) (s storage.Storer, fs billy.Filesystem, tempPath string, err error) {
+) (s storage.Storer, fs billy.Filesystem, tempPath string, bl transactionBaseline, err error) {
 	fs, err = l.fs.Chroot(l.RepositoryPath(id))
 	if err != nil {
-		return nil, nil, "", err
+		return nil, nil, "", bl, err
 	}
 
 	c := l.opts.Cache
@@ -102,15 +144,31 @@ func repositoryStorer(
 
 	switch mode {
 	case borges.ReadOnlyMode:
-		return &util.ReadOnlyStorer{Storer: s}, fs, "", nil
+		return &util.ReadOnlyStorer{Storer: s}, fs, "", bl, nil
 	case borges.RWMode:
 		if l.opts.Transactional {
-			return repositoryTemporalStorer(l, id, s)
+			parent := s
+
+			bl, err := snapshotTransactionBaseline(parent)
+			if err != nil {
+				return nil, nil, "", bl, err
+			}
+
+			ts, tfs, tempPath, err := repositoryTemporalStorer(l, id, parent)
+			if err != nil {
+				return nil, nil, "", bl, err
+			}
+
+			bl.parent = parent
+			bl.temp = ts
+
+			full := transactional.NewStorage(parent, ts)
+			return newSelectiveStorage(parent, full, l.opts.TransactionalOptions), tfs, tempPath, bl, nil
 		}
 
-		return s, fs, "", nil
+		return s, fs, "", bl, nil
 	default:
-		return nil, nil, "", borges.ErrModeNotSupported.New(mode)
+		return nil, nil, "", bl, borges.ErrModeNotSupported.New(mode)
 	}
 }
 
@@ -129,12 +187,61 @@ func repositoryTemporalStorer(
 		return nil, nil, "", err
 	}
 
-	ts := filesystem.NewStorage(fs, cache.NewObjectLRUDefault())
-	s = transactional.NewStorage(parent, ts)
+	s = filesystem.NewStorage(fs, cache.NewObjectLRUDefault())
 
 	return
 }
 
+// snapshotTransactionBaseline records the state of every subsystem s
+// holds at the time a transaction is opened, so Commit can later tell
+// which of them changed in the parent storer while the transaction was
+// in progress. It always snapshots every subsystem: a Location can
+// change which subsystems its TransactionalOptions selects between opens
+// of the same repository, and the baseline has to be there if it does.
+func snapshotTransactionBaseline(s storage.Storer) (transactionBaseline, error) {
+	refs, err := snapshotReferences(s)
+	if err != nil {
+		return transactionBaseline{}, err
+	}
+
+	cfg, err := s.Config()
+	if err != nil {
+		return transactionBaseline{}, err
+	}
+
+	shallow, err := s.Shallow()
+	if err != nil {
+		return transactionBaseline{}, err
+	}
+
+	idx, err := s.Index()
+	if err != nil {
+		return transactionBaseline{}, err
+	}
+
+	return transactionBaseline{refs: refs, config: cfg, shallow: shallow, index: idx}, nil
+}
+
+// snapshotReferences returns every reference s holds keyed by name.
+func snapshotReferences(s storage.Storer) (map[plumbing.ReferenceName]*plumbing.Reference, error) {
+	iter, err := s.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	refs := map[plumbing.ReferenceName]*plumbing.Reference{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		refs[ref.Name()] = ref
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
 // R returns the git.Repository.
 func (r *Repository) R() *git.Repository {
 	return r.Repository
@@ -171,22 +278,195 @@ func (r *Repository) cleanupTemporal() error {
 
 // Commit persists all the write operations done since was open, if the
 // repository wasn't opened in a Location with Transactions enable returns
-// ErrNonTransactional.
+// ErrNonTransactional. Commit first checks every subsystem the Location's
+// TransactionalOptions selects against the parent storer; if any of them
+// changed concurrently, it returns a *CommitConflictError instead of
+// committing, so the caller can reload fresh state and retry.
 func (r *Repository) Commit() (err error) {
 	if !r.l.opts.Transactional {
 		return borges.ErrNonTransactional.New()
 	}
 
 	defer ioutil.CheckClose(r, &err)
-	ts, ok := r.Storer.(transactional.Storage)
+
+	topts := r.l.opts.TransactionalOptions
+
+	if topts.References {
+		if err := r.checkReferenceConflicts(); err != nil {
+			return err
+		}
+	}
+
+	if topts.Config {
+		if err := r.checkConfigConflict(); err != nil {
+			return err
+		}
+	}
+
+	if topts.Shallow {
+		if err := r.checkShallowConflict(); err != nil {
+			return err
+		}
+	}
+
+	if topts.Index {
+		if err := r.checkIndexConflict(); err != nil {
+			return err
+		}
+	}
+
+	s, ok := r.Storer.(*selectiveStorage)
 	if !ok {
 		panic("unreachable code")
 	}
 
-	err = ts.Commit()
+	err = s.Commit()
 	return
 }
 
+// checkReferenceConflicts compares every reference touched in the
+// transaction against the parent storer's current value, applying the
+// Location's RefConflictPolicy to each mismatch. It returns a
+// *CommitConflictError naming the refs that conflicted and could not be
+// resolved. It only looks at r.temp, the storer the transaction actually
+// wrote to, not r.Storer, whose IterReferences returns every ref visible
+// through the parent, touched by this transaction or not.
+func (r *Repository) checkReferenceConflicts() error {
+	iter, err := r.temp.IterReferences()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	policy := r.l.opts.TransactionalOptions.RefConflictPolicy
+
+	var conflicts []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		opened := r.baseline[ref.Name()]
+
+		current, err := r.parent.Reference(ref.Name())
+		if err != nil && err != plumbing.ErrReferenceNotFound {
+			return err
+		}
+
+		if !referencesEqual(opened, current) {
+			switch policy {
+			case IgnoreOldRef:
+				// keep the transaction's value, nothing to do.
+			case CustomRefConflict:
+				resolved, err := r.l.opts.TransactionalOptions.Resolve(opened, current)
+				if err != nil {
+					return err
+				}
+
+				if resolved != nil && resolved.Hash() != ref.Hash() {
+					conflicts = append(conflicts, ref.Name().String())
+				}
+			default:
+				conflicts = append(conflicts, ref.Name().String())
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return &CommitConflictError{Refs: conflicts}
+	}
+
+	return nil
+}
+
+func referencesEqual(a, b *plumbing.Reference) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Hash() == b.Hash()
+}
+
+// checkConfigConflict fails Commit with a *CommitConflictError if the
+// parent storer's config changed since the transaction was opened. There
+// is no per-value RefConflictPolicy equivalent for config: any change
+// conflicts.
+func (r *Repository) checkConfigConflict() error {
+	current, err := r.parent.Config()
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(r.configBaseline, current) {
+		return &CommitConflictError{Refs: []string{"config"}}
+	}
+
+	return nil
+}
+
+// checkShallowConflict fails Commit with a *CommitConflictError if the
+// parent storer's shallow-commit list changed since the transaction was
+// opened.
+func (r *Repository) checkShallowConflict() error {
+	current, err := r.parent.Shallow()
+	if err != nil {
+		return err
+	}
+
+	if !sameHashes(r.shallowBaseline, current) {
+		return &CommitConflictError{Refs: []string{"shallow"}}
+	}
+
+	return nil
+}
+
+// checkIndexConflict fails Commit with a *CommitConflictError if the
+// parent storer's index changed since the transaction was opened.
+func (r *Repository) checkIndexConflict() error {
+	current, err := r.parent.Index()
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(r.indexBaseline, current) {
+		return &CommitConflictError{Refs: []string{"index"}}
+	}
+
+	return nil
+}
+
+func sameHashes(a, b []plumbing.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	seen := make(map[plumbing.Hash]struct{}, len(a))
+	for _, h := range a {
+		seen[h] = struct{}{}
+	}
+
+	for _, h := range b {
+		if _, ok := seen[h]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Rollback implements the borges.Repository interface. In transactional
+// mode it discards the temporal storage without ever touching the parent
+// one, leaving the repository as it was before it was opened. Outside
+// transactional mode there is no pending state to undo, so it is a no-op.
+func (r *Repository) Rollback() error {
+	if !r.l.opts.Transactional {
+		return nil
+	}
+
+	return r.cleanupTemporal()
+}
+
 // FS returns the filesystem to read or write directly to the repository or
 // nil if not available.
 func (r *Repository) FS() billy.Filesystem {
@@ -0,0 +1,100 @@
+package plain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func newTestRepository(t *testing.T, opts TransactionalOptions) (*Repository, *memory.Storage) {
+	t.Helper()
+
+	parent := memory.NewStorage()
+	temp := memory.NewStorage()
+
+	baseline, err := snapshotReferences(parent)
+	require.NoError(t, err)
+
+	return &Repository{
+		l:        NewLocation("loc", nil, &LocationOptions{Transactional: true, TransactionalOptions: opts}),
+		parent:   parent,
+		temp:     temp,
+		baseline: baseline,
+	}, parent
+}
+
+func setRef(t *testing.T, s interface {
+	SetReference(*plumbing.Reference) error
+}, name string, hash string) {
+	t.Helper()
+
+	err := s.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), plumbing.NewHash(hash)))
+	require.NoError(t, err)
+}
+
+// TestCheckReferenceConflictsIgnoresUnrelatedRefs makes sure a concurrent
+// change to a ref the transaction never touched does not trip
+// checkReferenceConflicts: only refs actually written to r.temp are
+// checked against the parent's current value.
+func TestCheckReferenceConflictsIgnoresUnrelatedRefs(t *testing.T) {
+	hash1 := strings.Repeat("a", 40)
+	hash2 := strings.Repeat("b", 40)
+	hash3 := strings.Repeat("c", 40)
+
+	r, parent := newTestRepository(t, TransactionalOptions{
+		References:        true,
+		RefConflictPolicy: ErrRefHasChanged,
+	})
+
+	setRef(t, parent, "refs/heads/master", hash1)
+
+	baseline, err := snapshotReferences(parent)
+	require.NoError(t, err)
+	r.baseline = baseline
+
+	// The transaction only wrote refs/heads/feature.
+	setRef(t, r.temp, "refs/heads/feature", hash2)
+
+	// A concurrent, unrelated change lands on master, which this
+	// transaction never touched.
+	setRef(t, parent, "refs/heads/master", hash3)
+
+	require.NoError(t, r.checkReferenceConflicts())
+}
+
+// TestCheckReferenceConflictsDetectsConflict makes sure a concurrent
+// change to a ref the transaction did write is reported as a conflict
+// under the ErrRefHasChanged policy.
+func TestCheckReferenceConflictsDetectsConflict(t *testing.T) {
+	hash1 := strings.Repeat("a", 40)
+	hash3 := strings.Repeat("c", 40)
+	hash4 := strings.Repeat("d", 40)
+
+	r, parent := newTestRepository(t, TransactionalOptions{
+		References:        true,
+		RefConflictPolicy: ErrRefHasChanged,
+	})
+
+	setRef(t, parent, "refs/heads/master", hash1)
+
+	baseline, err := snapshotReferences(parent)
+	require.NoError(t, err)
+	r.baseline = baseline
+
+	// The transaction wrote a new value for master.
+	setRef(t, r.temp, "refs/heads/master", hash4)
+
+	// A concurrent change also lands on master in the parent.
+	setRef(t, parent, "refs/heads/master", hash3)
+
+	err = r.checkReferenceConflicts()
+	require.Error(t, err)
+
+	conflictErr, ok := err.(*CommitConflictError)
+	require.True(t, ok)
+	require.Equal(t, []string{"refs/heads/master"}, conflictErr.Refs)
+}
@@ -0,0 +1,132 @@
+package plain
+
+import (
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/storage"
+	"gopkg.in/src-d/go-git.v4/storage/transactional"
+)
+
+// selectiveStorage composes storage.Storer from full, routing each of the
+// references, config, shallow, and index subsystems back to parent instead
+// whenever opts leaves that subsystem out. Everything else — most notably
+// object storage (SetEncodedObject, EncodedObject, IterEncodedObjects, ...)
+// — is never overridden, so it always goes through full: every object a
+// transactional Repository writes lands in the temporal storer until
+// Commit, regardless of which subsystems opts selects, so Rollback can
+// still discard it.
+type selectiveStorage struct {
+	storage.Storer // full; handles every method not overridden below
+
+	parent storage.Storer
+	opts   TransactionalOptions
+}
+
+func newSelectiveStorage(parent, full storage.Storer, opts TransactionalOptions) *selectiveStorage {
+	return &selectiveStorage{Storer: full, parent: parent, opts: opts}
+}
+
+// Commit persists the pending writes of every subsystem opts selected.
+// Subsystems left out of opts never wrote to full's temporal storer in
+// the first place, so committing them here is a no-op.
+func (s *selectiveStorage) Commit() error {
+	ts, ok := s.Storer.(transactional.Storage)
+	if !ok {
+		panic("unreachable code")
+	}
+
+	return ts.Commit()
+}
+
+func (s *selectiveStorage) SetReference(ref *plumbing.Reference) error {
+	if s.opts.References {
+		return s.Storer.SetReference(ref)
+	}
+	return s.parent.SetReference(ref)
+}
+
+func (s *selectiveStorage) CheckAndSetReference(new, old *plumbing.Reference) error {
+	if s.opts.References {
+		return s.Storer.CheckAndSetReference(new, old)
+	}
+	return s.parent.CheckAndSetReference(new, old)
+}
+
+func (s *selectiveStorage) Reference(name plumbing.ReferenceName) (*plumbing.Reference, error) {
+	if s.opts.References {
+		return s.Storer.Reference(name)
+	}
+	return s.parent.Reference(name)
+}
+
+func (s *selectiveStorage) IterReferences() (storer.ReferenceIter, error) {
+	if s.opts.References {
+		return s.Storer.IterReferences()
+	}
+	return s.parent.IterReferences()
+}
+
+func (s *selectiveStorage) RemoveReference(name plumbing.ReferenceName) error {
+	if s.opts.References {
+		return s.Storer.RemoveReference(name)
+	}
+	return s.parent.RemoveReference(name)
+}
+
+func (s *selectiveStorage) CountLooseRefs() (int, error) {
+	if s.opts.References {
+		return s.Storer.CountLooseRefs()
+	}
+	return s.parent.CountLooseRefs()
+}
+
+func (s *selectiveStorage) PackRefs() error {
+	if s.opts.References {
+		return s.Storer.PackRefs()
+	}
+	return s.parent.PackRefs()
+}
+
+func (s *selectiveStorage) Config() (*config.Config, error) {
+	if s.opts.Config {
+		return s.Storer.Config()
+	}
+	return s.parent.Config()
+}
+
+func (s *selectiveStorage) SetConfig(cfg *config.Config) error {
+	if s.opts.Config {
+		return s.Storer.SetConfig(cfg)
+	}
+	return s.parent.SetConfig(cfg)
+}
+
+func (s *selectiveStorage) Shallow() ([]plumbing.Hash, error) {
+	if s.opts.Shallow {
+		return s.Storer.Shallow()
+	}
+	return s.parent.Shallow()
+}
+
+func (s *selectiveStorage) SetShallow(hashes []plumbing.Hash) error {
+	if s.opts.Shallow {
+		return s.Storer.SetShallow(hashes)
+	}
+	return s.parent.SetShallow(hashes)
+}
+
+func (s *selectiveStorage) Index() (*index.Index, error) {
+	if s.opts.Index {
+		return s.Storer.Index()
+	}
+	return s.parent.Index()
+}
+
+func (s *selectiveStorage) SetIndex(idx *index.Index) error {
+	if s.opts.Index {
+		return s.Storer.SetIndex(idx)
+	}
+	return s.parent.SetIndex(idx)
+}
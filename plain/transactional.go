@@ -0,0 +1,54 @@
+package plain
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// RefConflictPolicy controls how Repository.Commit resolves a reference
+// that changed in the parent storer while a transaction was open.
+type RefConflictPolicy int
+
+const (
+	// IgnoreOldRef always commits the transaction's value for a
+	// reference, discarding whatever the parent storer ended up with.
+	IgnoreOldRef RefConflictPolicy = iota
+	// ErrRefHasChanged fails Commit with a *CommitConflictError listing
+	// every reference whose parent value no longer matches what it was
+	// when the transaction was opened.
+	ErrRefHasChanged
+	// CustomRefConflict delegates the decision to
+	// TransactionalOptions.Resolve.
+	CustomRefConflict
+)
+
+// TransactionalOptions selects which storage subsystems of a
+// transactional Repository are actually made transactional: staged in
+// the temporal storer and checked for conflicts on Commit instead of
+// being written straight through to the parent storer immediately, the
+// same as outside transactional mode. A subsystem left disabled here
+// ignores Rollback too, since there is nothing staged for it to discard.
+type TransactionalOptions struct {
+	// References makes reference writes transactional and enables
+	// reference conflict detection in Commit.
+	References bool
+	// Config makes config writes transactional and enables config
+	// conflict detection in Commit.
+	Config bool
+	// Shallow makes shallow-commit list writes transactional and enables
+	// shallow conflict detection in Commit.
+	Shallow bool
+	// Index makes index writes transactional and enables index conflict
+	// detection in Commit.
+	Index bool
+
+	RefConflictPolicy RefConflictPolicy
+	// Resolve is consulted for every conflicting reference when
+	// RefConflictPolicy is CustomRefConflict. It receives the reference
+	// as it was when the transaction opened (nil if it did not exist
+	// yet) and as it currently is in the parent storer (nil if it has
+	// since been deleted), and returns the value that should be treated
+	// as non-conflicting, or an error to abort the commit outright.
+	// Config, Shallow, and Index have no equivalent: any change to them
+	// detected on Commit always conflicts.
+	Resolve func(opened, current *plumbing.Reference) (*plumbing.Reference, error)
+}
@@ -0,0 +1,96 @@
+package plain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// TestTransactionalCommitStagesObjectsUntilCommit makes sure an object
+// written during a transaction is only visible on the parent's real
+// filesystem once Commit is called, not as soon as it is written:
+// object storage has no TransactionalOptions flag of its own, so it must
+// always go through the temporal storer while a transaction is open.
+func TestTransactionalCommitStagesObjectsUntilCommit(t *testing.T) {
+	require := require.New(t)
+
+	fs := memfs.New()
+	loc := NewLocation("loc", fs, &LocationOptions{
+		Transactional:        true,
+		TemporalFilesystem:   memfs.New(),
+		TransactionalOptions: TransactionalOptions{References: true},
+	})
+
+	repo, err := loc.Init("repo-id")
+	require.NoError(err)
+
+	obj := repo.R().Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	require.NoError(err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(err)
+	require.NoError(w.Close())
+
+	hash, err := repo.R().Storer.SetEncodedObject(obj)
+	require.NoError(err)
+
+	parentStorer := openParentStorer(t, fs, "repo-id")
+
+	_, err = parentStorer.EncodedObject(plumbing.BlobObject, hash)
+	require.Equal(plumbing.ErrObjectNotFound, err, "object must not reach the parent storer before Commit")
+
+	require.NoError(repo.Commit())
+
+	_, err = parentStorer.EncodedObject(plumbing.BlobObject, hash)
+	require.NoError(err, "object must reach the parent storer once Commit succeeds")
+}
+
+// TestTransactionalRollbackDiscardsObjects makes sure an object written
+// during a transaction never reaches the parent storer at all if the
+// transaction is rolled back instead of committed.
+func TestTransactionalRollbackDiscardsObjects(t *testing.T) {
+	require := require.New(t)
+
+	fs := memfs.New()
+	loc := NewLocation("loc", fs, &LocationOptions{
+		Transactional:        true,
+		TemporalFilesystem:   memfs.New(),
+		TransactionalOptions: TransactionalOptions{References: true},
+	})
+
+	repo, err := loc.Init("repo-id")
+	require.NoError(err)
+
+	obj := repo.R().Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	require.NoError(err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(err)
+	require.NoError(w.Close())
+
+	hash, err := repo.R().Storer.SetEncodedObject(obj)
+	require.NoError(err)
+
+	require.NoError(repo.Rollback())
+
+	parentStorer := openParentStorer(t, fs, "repo-id")
+	_, err = parentStorer.EncodedObject(plumbing.BlobObject, hash)
+	require.Equal(plumbing.ErrObjectNotFound, err, "a rolled back transaction must never persist its objects")
+}
+
+func openParentStorer(t *testing.T, fs billy.Filesystem, path string) *filesystem.Storage {
+	t.Helper()
+
+	repoFS, err := fs.Chroot(path)
+	require.NoError(t, err)
+
+	return filesystem.NewStorage(repoFS, cache.NewObjectLRUDefault())
+}
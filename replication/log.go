@@ -0,0 +1,159 @@
+// Package replication records every mutation performed through a
+// borges.Library into an append-only log and replays it against another
+// library, inspired by git-bug's operation-pack model where every change
+// is itself stored as a commit in git.
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Operation records a single mutation performed through a borges.Library:
+// a repository Init, Commit, Rollback, or the ref updates that went with
+// one of those.
+type Operation struct {
+	Timestamp    time.Time           `json:"timestamp"`
+	LibraryID    borges.LibraryID    `json:"library_id"`
+	LocationID   borges.LocationID   `json:"location_id"`
+	RepositoryID borges.RepositoryID `json:"repository_id"`
+	RefUpdates   map[string]string   `json:"ref_updates,omitempty"`
+	ShaBefore    string              `json:"sha_before,omitempty"`
+	ShaAfter     string              `json:"sha_after,omitempty"`
+}
+
+// ReplicationLog is an append-only log of Operations.
+type ReplicationLog interface {
+	// Append records op at the head of the log.
+	Append(ctx context.Context, op Operation) error
+	// Since returns every operation appended after since, oldest first.
+	Since(ctx context.Context, since time.Time) ([]Operation, error)
+}
+
+// DefaultRef is the hidden ref GitLog appends its operations to.
+const DefaultRef = plumbing.ReferenceName("refs/borges/replication/log")
+
+// emptyTreeHash is the well-known hash of the empty git tree, used as
+// every log commit's tree since the payload lives in the commit message.
+var emptyTreeHash = plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+
+// GitLog is a ReplicationLog stored as commits on a hidden ref of a
+// dedicated "meta" git.Repository, so it replicates and is backed up like
+// any other ref without needing storage of its own.
+type GitLog struct {
+	repo *git.Repository
+	ref  plumbing.ReferenceName
+
+	// mu serializes Append so two concurrent writers can't both read the
+	// same head and race to set the ref, which would silently drop one
+	// of their commits from the log.
+	mu sync.Mutex
+}
+
+// NewGitLog creates a GitLog appending to DefaultRef of repo.
+func NewGitLog(repo *git.Repository) *GitLog {
+	return &GitLog{repo: repo, ref: DefaultRef}
+}
+
+// Append implements ReplicationLog.
+func (l *GitLog) Append(ctx context.Context, op Operation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	payload, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	commit := &object.Commit{
+		Author:    object.Signature{Name: "go-borges", When: op.Timestamp},
+		Committer: object.Signature{Name: "go-borges", When: op.Timestamp},
+		Message:   string(payload),
+		TreeHash:  emptyTreeHash,
+	}
+
+	head, err := l.repo.Storer.Reference(l.ref)
+	switch err {
+	case nil:
+		commit.ParentHashes = []plumbing.Hash{head.Hash()}
+	case plumbing.ErrReferenceNotFound:
+	default:
+		return err
+	}
+
+	obj := l.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return err
+	}
+
+	hash, err := l.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return l.repo.Storer.SetReference(plumbing.NewHashReference(l.ref, hash))
+}
+
+// Since implements ReplicationLog.
+func (l *GitLog) Since(ctx context.Context, since time.Time) ([]Operation, error) {
+	head, err := l.repo.Storer.Reference(l.ref)
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	hash := head.Hash()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		obj, err := l.repo.Storer.EncodedObject(plumbing.CommitObject, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		commit := &object.Commit{}
+		if err := commit.Decode(obj); err != nil {
+			return nil, err
+		}
+
+		var op Operation
+		if err := json.Unmarshal([]byte(commit.Message), &op); err != nil {
+			return nil, err
+		}
+
+		if !op.Timestamp.After(since) {
+			break
+		}
+
+		ops = append(ops, op)
+
+		if len(commit.ParentHashes) == 0 {
+			break
+		}
+
+		hash = commit.ParentHashes[0]
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops, nil
+}
@@ -0,0 +1,123 @@
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/revlist"
+)
+
+// Replay applies every operation appended to log after since against
+// target, fetching only the objects each operation's ref updates
+// introduced rather than scanning the whole source library. source must
+// be the library the log was recorded from: it is where the objects the
+// ref updates point to actually live. This lets a siva-backed library be
+// mirrored onto a plain-backed one (typically an overlay or backup
+// target) without a full `git fetch --all`.
+func Replay(ctx context.Context, log ReplicationLog, source, target borges.Library, since time.Time) error {
+	ops, err := log.Since(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := replayOperation(ctx, source, target, op); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func replayOperation(ctx context.Context, source, target borges.Library, op Operation) error {
+	if len(op.RefUpdates) == 0 {
+		return nil
+	}
+
+	src, err := source.Get(op.RepositoryID, borges.ReadOnlyMode)
+	if err != nil {
+		return err
+	}
+
+	repo, err := target.GetOrInit(op.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	if err := fetchRefUpdates(ctx, src, repo, op.RefUpdates); err != nil {
+		return err
+	}
+
+	return repo.Commit()
+}
+
+// fetchRefUpdates copies into dst every object reachable from the refs
+// being updated that dst does not already have, then points dst's refs at
+// the values in updates. An empty hash in updates means the ref was
+// deleted upstream, which is applied to dst as a ref removal rather than
+// a fetch.
+func fetchRefUpdates(ctx context.Context, src, dst borges.Repository, updates map[string]string) error {
+	haveRefs, err := snapshotRefs(dst)
+	if err != nil {
+		return err
+	}
+
+	haves := make([]plumbing.Hash, 0, len(haveRefs))
+	for _, hash := range haveRefs {
+		haves = append(haves, plumbing.NewHash(hash))
+	}
+
+	var wants []plumbing.Hash
+	for name, hash := range updates {
+		if hash == "" {
+			if err := dst.R().Storer.RemoveReference(plumbing.ReferenceName(name)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		wants = append(wants, plumbing.NewHash(hash))
+	}
+
+	if len(wants) > 0 {
+		missing, err := revlist.Objects(src.R().Storer, wants, haves)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range missing {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			obj, err := src.R().Storer.EncodedObject(plumbing.AnyObject, hash)
+			if err != nil {
+				return err
+			}
+
+			if _, err := dst.R().Storer.SetEncodedObject(obj); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, hash := range updates {
+		if hash == "" {
+			continue
+		}
+
+		ref := plumbing.NewHashReference(plumbing.ReferenceName(name), plumbing.NewHash(hash))
+		if err := dst.R().Storer.SetReference(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
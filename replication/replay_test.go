@@ -0,0 +1,103 @@
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-borges"
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// stubRepository is a borges.Repository backed by a real in-memory
+// git.Repository, enough to exercise fetchRefUpdates' object transfer.
+type stubRepository struct {
+	r *git.Repository
+}
+
+func newStubRepository(t *testing.T) *stubRepository {
+	t.Helper()
+
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+
+	return &stubRepository{r: r}
+}
+
+func (s *stubRepository) ID() borges.RepositoryID       { return "repo" }
+func (s *stubRepository) LocationID() borges.LocationID { return "loc" }
+func (s *stubRepository) Mode() borges.Mode             { return borges.RWMode }
+func (s *stubRepository) Commit() error                 { return nil }
+func (s *stubRepository) Rollback() error                { return nil }
+func (s *stubRepository) R() *git.Repository            { return s.r }
+
+func commitFile(t *testing.T, r *git.Repository, name, content string) string {
+	t.Helper()
+
+	w, err := r.Worktree()
+	require.NoError(t, err)
+
+	f, err := w.Filesystem.Create(name)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = w.Add(name)
+	require.NoError(t, err)
+
+	sig := &object.Signature{Name: "tester", Email: "tester@example.com", When: time.Unix(0, 0)}
+	hash, err := w.Commit("add "+name, &git.CommitOptions{Author: sig})
+	require.NoError(t, err)
+
+	return hash.String()
+}
+
+// TestFetchRefUpdatesCopiesObjects makes sure fetchRefUpdates transfers the
+// objects a ref update introduces, not just the ref itself: the target
+// must be able to resolve the commit, and its parent, after the fetch.
+func TestFetchRefUpdatesCopiesObjects(t *testing.T) {
+	require := require.New(t)
+
+	src := newStubRepository(t)
+	commitFile(t, src.r, "a", "a")
+	head := commitFile(t, src.r, "b", "b")
+
+	dst := newStubRepository(t)
+
+	updates := map[string]string{"refs/heads/master": head}
+	require.NoError(fetchRefUpdates(context.Background(), src, dst, updates))
+
+	ref, err := dst.r.Storer.Reference("refs/heads/master")
+	require.NoError(err)
+	require.Equal(head, ref.Hash().String())
+
+	commit, err := dst.r.CommitObject(ref.Hash())
+	require.NoError(err)
+	require.Len(commit.ParentHashes, 1)
+
+	_, err = dst.r.CommitObject(commit.ParentHashes[0])
+	require.NoError(err, "fetchRefUpdates must copy parent commits, not just the ref tip")
+}
+
+// TestFetchRefUpdatesAppliesDeletions makes sure an empty hash in updates
+// removes the ref from the target instead of being treated as a fetch.
+func TestFetchRefUpdatesAppliesDeletions(t *testing.T) {
+	require := require.New(t)
+
+	src := newStubRepository(t)
+	dst := newStubRepository(t)
+
+	commitFile(t, dst.r, "a", "a")
+
+	updates := map[string]string{"refs/heads/master": ""}
+	require.NoError(fetchRefUpdates(context.Background(), src, dst, updates))
+
+	_, err := dst.r.Storer.Reference("refs/heads/master")
+	require.Error(err)
+}
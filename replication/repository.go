@@ -0,0 +1,132 @@
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/src-d/go-borges"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// wrappedRepository appends a log Operation every time Commit or Rollback
+// is called on it.
+type wrappedRepository struct {
+	borges.Repository
+	log       ReplicationLog
+	libraryID borges.LibraryID
+
+	// before and beforeHead are a snapshot of every ref, and of HEAD, taken
+	// when the repository was wrapped, i.e. before the caller made any
+	// write against it. A transactional storer serves reads-of-its-own-
+	// writes, so taking either snapshot later, right before Commit, would
+	// already reflect the transaction's pending writes.
+	before     map[string]string
+	beforeHead string
+}
+
+var _ borges.Repository = (*wrappedRepository)(nil)
+
+func wrapRepository(r borges.Repository, log ReplicationLog, libraryID borges.LibraryID) (*wrappedRepository, error) {
+	before, err := snapshotRefs(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedRepository{
+		Repository: r,
+		log:        log,
+		libraryID:  libraryID,
+		before:     before,
+		beforeHead: headHash(r),
+	}, nil
+}
+
+// Commit implements the borges.Repository interface.
+func (w *wrappedRepository) Commit() error {
+	if err := w.Repository.Commit(); err != nil {
+		return err
+	}
+
+	after, err := snapshotRefs(w.Repository)
+	if err != nil {
+		return err
+	}
+
+	return w.log.Append(context.Background(), Operation{
+		Timestamp:    time.Now(),
+		LibraryID:    w.libraryID,
+		LocationID:   w.Repository.LocationID(),
+		RepositoryID: w.Repository.ID(),
+		RefUpdates:   diffRefs(w.before, after),
+		ShaBefore:    w.beforeHead,
+		ShaAfter:     headHash(w.Repository),
+	})
+}
+
+// Rollback implements the borges.Repository interface.
+func (w *wrappedRepository) Rollback() error {
+	if err := w.Repository.Rollback(); err != nil {
+		return err
+	}
+
+	return w.log.Append(context.Background(), Operation{
+		Timestamp:    time.Now(),
+		LibraryID:    w.libraryID,
+		LocationID:   w.Repository.LocationID(),
+		RepositoryID: w.Repository.ID(),
+	})
+}
+
+func headHash(r borges.Repository) string {
+	head, err := r.R().Head()
+	if err != nil {
+		return ""
+	}
+
+	return head.Hash().String()
+}
+
+// snapshotRefs returns every hash reference of r keyed by full ref name.
+func snapshotRefs(r borges.Repository) (map[string]string, error) {
+	iter, err := r.R().References()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	refs := map[string]string{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		refs[ref.Name().String()] = ref.Hash().String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// diffRefs returns the refs in after whose hash differs from (or is
+// missing in) before, plus every ref present in before but gone from
+// after, reported with an empty hash to mark it as deleted.
+func diffRefs(before, after map[string]string) map[string]string {
+	updates := map[string]string{}
+	for name, hash := range after {
+		if before[name] != hash {
+			updates[name] = hash
+		}
+	}
+
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			updates[name] = ""
+		}
+	}
+
+	return updates
+}
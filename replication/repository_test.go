@@ -0,0 +1,34 @@
+package replication
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRefs(t *testing.T) {
+	require := require.New(t)
+
+	hashA := strings.Repeat("a", 40)
+	hashB := strings.Repeat("b", 40)
+	hashC := strings.Repeat("c", 40)
+	hashD := strings.Repeat("d", 40)
+
+	before := map[string]string{
+		"refs/heads/master": hashA,
+		"refs/heads/stale":  hashB,
+	}
+	after := map[string]string{
+		"refs/heads/master": hashC,
+		"refs/heads/new":    hashD,
+	}
+
+	updates := diffRefs(before, after)
+
+	require.Equal(map[string]string{
+		"refs/heads/master": hashC,
+		"refs/heads/new":    hashD,
+		"refs/heads/stale":  "",
+	}, updates)
+}
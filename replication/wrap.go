@@ -0,0 +1,104 @@
+package replication
+
+import (
+	"context"
+	"time"
+
+	"github.com/src-d/go-borges"
+)
+
+// Wrap decorates lib so that every repository created through its Init or
+// GetOrInit is appended to log, and so are Commit and Rollback on every
+// borges.Repository its Init, GetOrInit, Get, or GetContext returns. Get and
+// GetContext themselves append nothing.
+func Wrap(lib borges.Library, log ReplicationLog) borges.Library {
+	return &wrappedLibrary{Library: lib, log: log, libraryID: libraryID(lib)}
+}
+
+// libraryID probes lib for an optional ID() method, since borges.Library
+// itself does not require one.
+func libraryID(lib borges.Library) borges.LibraryID {
+	if identified, ok := lib.(interface{ ID() borges.LibraryID }); ok {
+		return identified.ID()
+	}
+
+	return ""
+}
+
+type wrappedLibrary struct {
+	borges.Library
+	log       ReplicationLog
+	libraryID borges.LibraryID
+}
+
+var _ borges.Library = (*wrappedLibrary)(nil)
+
+// Init implements the borges.Library interface.
+func (w *wrappedLibrary) Init(id borges.RepositoryID) (borges.Repository, error) {
+	r, err := w.Library.Init(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.log.Append(context.Background(), Operation{
+		Timestamp:    time.Now(),
+		LibraryID:    w.libraryID,
+		LocationID:   r.LocationID(),
+		RepositoryID: id,
+	}); err != nil {
+		return nil, err
+	}
+
+	return wrapRepository(r, w.log, w.libraryID)
+}
+
+// GetOrInit implements the borges.Library interface. When it creates the
+// repository, the creation is appended to the log just like Init does.
+func (w *wrappedLibrary) GetOrInit(id borges.RepositoryID) (borges.Repository, error) {
+	existed, _, _, err := w.Library.Has(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := w.Library.GetOrInit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !existed {
+		if err := w.log.Append(context.Background(), Operation{
+			Timestamp:    time.Now(),
+			LibraryID:    w.libraryID,
+			LocationID:   r.LocationID(),
+			RepositoryID: id,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return wrapRepository(r, w.log, w.libraryID)
+}
+
+// Get implements the borges.Library interface.
+func (w *wrappedLibrary) Get(id borges.RepositoryID, mode borges.Mode) (borges.Repository, error) {
+	r, err := w.Library.Get(id, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapRepository(r, w.log, w.libraryID)
+}
+
+// GetContext implements the borges.Library interface.
+func (w *wrappedLibrary) GetContext(
+	ctx context.Context,
+	id borges.RepositoryID,
+	mode borges.Mode,
+) (borges.Repository, error) {
+	r, err := w.Library.GetContext(ctx, id, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapRepository(r, w.log, w.libraryID)
+}